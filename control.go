@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Response is a single parsed reply to a SendCommand call, covering the
+// "nnn-", "nnn+", and terminating "nnn " reply lines described by
+// control-spec.txt section 2.3.
+type Response struct {
+	Code  int
+	Lines []string
+}
+
+// replyResult is what readLoop hands back to whichever SendCommand call is
+// currently waiting on replies.
+type replyResult struct {
+	resp *Response
+	err  error
+}
+
+// Conn is a structured client for the Tor control-port protocol, modelled
+// on bine's control.Conn. A single background goroutine (readLoop) owns the
+// wire: it demuxes asynchronous "650 ..." lines onto Events() and routes
+// everything else into the reply for whichever SendCommand is in flight,
+// so a command issued after SETEVENTS never races event delivery for bytes
+// off the socket.
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex // serializes SendCommand against itself; only one reply is ever in flight
+
+	events  chan string
+	replies chan replyResult
+}
+
+// NewConn wraps an already-dialed connection to a Tor control port and
+// starts the connection's single reader goroutine.
+func NewConn(conn net.Conn) *Conn {
+	c := &Conn{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		events:  make(chan string, 64),
+		replies: make(chan replyResult),
+	}
+	go c.readLoop()
+	return c
+}
+
+// SendCommand writes a command line and waits for readLoop to assemble its
+// (possibly multi-line) reply.
+func (c *Conn) SendCommand(cmd string, args ...string) (*Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	line := cmd
+	if len(args) > 0 {
+		line += " " + strings.Join(args, " ")
+	}
+	if _, err := fmt.Fprintf(c.conn, "%s\r\n", line); err != nil {
+		return nil, err
+	}
+
+	result, ok := <-c.replies
+	if !ok {
+		return nil, fmt.Errorf("control connection closed")
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	if result.resp.Code >= 400 {
+		return result.resp, fmt.Errorf("control error %d: %s", result.resp.Code, strings.Join(result.resp.Lines, "; "))
+	}
+	return result.resp, nil
+}
+
+func (c *Conn) queueEvent(line string) {
+	select {
+	case c.events <- line:
+	default:
+		// Event buffer is full; drop rather than block the reader.
+	}
+}
+
+// Events returns the channel asynchronous "650 ..." lines are delivered on.
+func (c *Conn) Events() <-chan string { return c.events }
+
+// readLoop is the connection's sole reader. It runs for the lifetime of the
+// connection, closing Events() and the in-flight reply (if any) when the
+// wire is closed or a read error occurs.
+func (c *Conn) readLoop() {
+	var resp *Response
+
+	fail := func(err error) {
+		close(c.events)
+		if resp != nil {
+			c.replies <- replyResult{err: err}
+		}
+		close(c.replies)
+	}
+
+	for {
+		raw, err := c.reader.ReadString('\n')
+		if err != nil {
+			fail(err)
+			return
+		}
+		raw = strings.TrimRight(raw, "\r\n")
+
+		if resp == nil && strings.HasPrefix(raw, "650") {
+			c.queueEvent(raw)
+			continue
+		}
+
+		if len(raw) < 4 {
+			c.replies <- replyResult{err: fmt.Errorf("malformed control reply: %q", raw)}
+			resp = nil
+			continue
+		}
+
+		code, err := strconv.Atoi(raw[:3])
+		if err != nil {
+			c.replies <- replyResult{err: fmt.Errorf("malformed control reply code: %q", raw)}
+			resp = nil
+			continue
+		}
+
+		if resp == nil {
+			resp = &Response{}
+		}
+		sep, body := raw[3], raw[4:]
+		resp.Code = code
+		resp.Lines = append(resp.Lines, body)
+
+		if sep == '+' {
+			// Multi-line data block terminated by a lone ".".
+			for {
+				dataLine, err := c.reader.ReadString('\n')
+				if err != nil {
+					fail(err)
+					return
+				}
+				dataLine = strings.TrimRight(dataLine, "\r\n")
+				if dataLine == "." {
+					break
+				}
+				resp.Lines[len(resp.Lines)-1] += "\n" + dataLine
+			}
+			continue
+		}
+
+		if sep == ' ' {
+			c.replies <- replyResult{resp: resp}
+			resp = nil
+		}
+	}
+}
+
+func (c *Conn) Close() error { return c.conn.Close() }
+
+// splitKeywords tokenizes a Tor control-line argument list on spaces,
+// treating a double-quoted substring as a single token so that embedded
+// spaces and "=" characters inside it are not mistaken for separators.
+func splitKeywords(s string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inQuotes := false
+
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '"':
+			inQuotes = !inQuotes
+			cur.WriteByte(ch)
+		case ch == ' ' && !inQuotes:
+			flush()
+		default:
+			cur.WriteByte(ch)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// ParseKeywordArgs splits a Tor event line's argument tokens into bare
+// positional words and a map of KEY=VALUE pairs, unquoting quoted values.
+// This replaces the strings.Fields-based parsing that used to drop quoted
+// targets and misread values containing "=".
+func ParseKeywordArgs(tokens []string) (positional []string, kv map[string]string) {
+	kv = make(map[string]string)
+	for _, tok := range tokens {
+		if eq := strings.IndexByte(tok, '='); eq > 0 && tok[0] != '"' {
+			key, val := tok[:eq], tok[eq+1:]
+			kv[key] = strings.Trim(val, `"`)
+			continue
+		}
+		positional = append(positional, strings.Trim(tok, `"`))
+	}
+	return positional, kv
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitKeywords(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{
+			name: "bare tokens",
+			in:   "650 STATUS_CLIENT NOTICE BOOTSTRAP PROGRESS=10 TAG=handshake_dir",
+			want: []string{"650", "STATUS_CLIENT", "NOTICE", "BOOTSTRAP", "PROGRESS=10", "TAG=handshake_dir"},
+		},
+		{
+			name: "quoted value with embedded space and equals sign is one token",
+			in:   `650 STATUS_CLIENT NOTICE BOOTSTRAP PROGRESS=100 TAG=done SUMMARY="Finishing handshake with directory server"`,
+			want: []string{"650", "STATUS_CLIENT", "NOTICE", "BOOTSTRAP", "PROGRESS=100", "TAG=done", `SUMMARY="Finishing handshake with directory server"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitKeywords(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitKeywords(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeywordArgs(t *testing.T) {
+	tests := []struct {
+		name           string
+		tokens         []string
+		wantPositional []string
+		wantKV         map[string]string
+	}{
+		{
+			name:           "positional and keyword tokens",
+			tokens:         splitKeywords("1 NEW 0 example.com:443"),
+			wantPositional: []string{"1", "NEW", "0", "example.com:443"},
+			wantKV:         map[string]string{},
+		},
+		{
+			name:           "quoted value with embedded space survives intact",
+			tokens:         splitKeywords(`NOTICE BOOTSTRAP PROGRESS=100 SUMMARY="Finishing handshake with directory server"`),
+			wantPositional: []string{"NOTICE", "BOOTSTRAP"},
+			wantKV:         map[string]string{"PROGRESS": "100", "SUMMARY": "Finishing handshake with directory server"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positional, kv := ParseKeywordArgs(tt.tokens)
+			if !reflect.DeepEqual(positional, tt.wantPositional) {
+				t.Errorf("positional = %#v, want %#v", positional, tt.wantPositional)
+			}
+			if !reflect.DeepEqual(kv, tt.wantKV) {
+				t.Errorf("kv = %#v, want %#v", kv, tt.wantKV)
+			}
+		})
+	}
+}
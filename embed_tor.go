@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cretz/bine/process"
+	"github.com/cretz/bine/tor"
+)
+
+// EmbeddedTorConfig configures the opt-in "embed_tor" mode: instead of
+// dialing a pre-existing ControlPort, omon launches and manages its own
+// child tor process via bine.
+type EmbeddedTorConfig struct {
+	TorPath            string `json:"tor_path,omitempty"`  // path to the tor binary; empty lets bine search PATH
+	DataDir            string `json:"data_dir,omitempty"`  // empty uses a temp dir managed by bine
+	LogLevel           string `json:"log_level,omitempty"` // e.g. "notice"; empty disables tor's own stdout logging
+	KeepDataDirOnClose bool   `json:"keep_data_dir_on_close,omitempty"`
+}
+
+// restartCooldown bounds how often Restart will actually relaunch tor, so a
+// persistently failing child process doesn't spin the parent in a tight loop.
+const restartCooldown = 30 * time.Second
+
+// EmbeddedTorProvider launches and owns a child tor process via bine. Once
+// the process is up, it delegates all control-port protocol handling -
+// authentication, SETEVENTS, stream/circuit parsing - to an embedded
+// TorProvider pointed at the managed process's control port and cookie.
+type EmbeddedTorProvider struct {
+	cfg EmbeddedTorConfig
+
+	mu             sync.Mutex
+	t              *tor.Tor
+	inner          *TorProvider
+	lastRestart    time.Time
+	statusCallback func(percent int, summary string) // re-applied to inner on every (re)launch, since Restart builds a new one
+}
+
+// NewEmbeddedTorProvider builds an EmbeddedTorProvider from its config.
+func NewEmbeddedTorProvider(cfg EmbeddedTorConfig) *EmbeddedTorProvider {
+	return &EmbeddedTorProvider{cfg: cfg}
+}
+
+func (p *EmbeddedTorProvider) Network() Network { return NetworkTor }
+
+// launch starts the child tor process and builds the inner TorProvider
+// that talks to it, auto-discovering the control port and cookie bine
+// generated rather than requiring them in omon.json.
+func (p *EmbeddedTorProvider) launch() error {
+	startConf := &tor.StartConf{
+		ExePath:           p.cfg.TorPath,
+		DataDir:           p.cfg.DataDir,
+		RetainTempDataDir: p.cfg.KeepDataDirOnClose,
+		NoAutoSocksPort:   true,
+		// Without this, bine passes --DisableNetwork 1, and tor never
+		// bootstraps past 0%.
+		EnableNetwork: true,
+		ExtraArgs:     []string{"--CookieAuthentication", "1"},
+		// ProcessCreator lets bine hide the console window on Windows
+		// builds instead of flashing up a cmd.exe for the child process.
+		ProcessCreator: process.NewCreator(p.cfg.TorPath),
+	}
+	if p.cfg.LogLevel != "" {
+		startConf.ExtraArgs = append(startConf.ExtraArgs, "--Log", p.cfg.LogLevel+" stdout")
+	}
+
+	t, err := tor.Start(context.Background(), startConf)
+	if err != nil {
+		return fmt.Errorf("failed to launch embedded tor: %v", err)
+	}
+
+	p.t = t
+	p.inner = NewTorProvider(ProviderConfig{
+		Type:        string(NetworkTor),
+		CookiePaths: []string{filepath.Join(t.DataDir, "control_auth_cookie")},
+		Ports:       []string{fmt.Sprintf("%d", t.ControlPort)},
+	})
+	if p.statusCallback != nil {
+		p.inner.SetStatusCallback(p.statusCallback)
+	}
+	return nil
+}
+
+// Connect launches the managed tor process (if not already running) and
+// connects the inner TorProvider to its control port.
+func (p *EmbeddedTorProvider) Connect() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Printf("%sLaunching embedded tor process...%s\n", ColorCyan, ColorReset)
+	if err := p.launch(); err != nil {
+		return err
+	}
+	return p.inner.Connect()
+}
+
+func (p *EmbeddedTorProvider) Authenticate() error {
+	p.mu.Lock()
+	inner := p.inner
+	p.mu.Unlock()
+	return inner.Authenticate()
+}
+
+func (p *EmbeddedTorProvider) SubscribeStreams() (<-chan Event, error) {
+	p.mu.Lock()
+	inner := p.inner
+	p.mu.Unlock()
+	return inner.SubscribeStreams()
+}
+
+func (p *EmbeddedTorProvider) SetStatusCallback(cb func(percent int, summary string)) {
+	p.mu.Lock()
+	p.statusCallback = cb
+	inner := p.inner
+	p.mu.Unlock()
+	inner.SetStatusCallback(cb)
+}
+
+func (p *EmbeddedTorProvider) WaitTillBootstrapped() {
+	p.mu.Lock()
+	inner := p.inner
+	p.mu.Unlock()
+	inner.WaitTillBootstrapped()
+}
+
+func (p *EmbeddedTorProvider) BootstrapPercent() int {
+	p.mu.Lock()
+	inner := p.inner
+	p.mu.Unlock()
+	return inner.BootstrapPercent()
+}
+
+func (p *EmbeddedTorProvider) Circuits() map[string]CircuitInfo {
+	p.mu.Lock()
+	inner := p.inner
+	p.mu.Unlock()
+	return inner.Circuits()
+}
+
+func (p *EmbeddedTorProvider) CountryForIP(ip string) (string, error) {
+	p.mu.Lock()
+	inner := p.inner
+	p.mu.Unlock()
+	return inner.CountryForIP(ip)
+}
+
+// Restart tears down the managed tor process and relaunches it, respecting
+// a cooldown so that a tor binary which keeps crashing on startup doesn't
+// get relaunched in a tight loop. Callers (the event loop, on persistent
+// read errors) should resubscribe after Restart returns successfully.
+func (p *EmbeddedTorProvider) Restart() error {
+	p.mu.Lock()
+	if since := time.Since(p.lastRestart); since < restartCooldown {
+		wait := restartCooldown - since
+		p.mu.Unlock()
+		fmt.Printf("%sWaiting %v before restarting embedded tor (cooldown)...%s\n", ColorYellow, wait.Round(time.Second), ColorReset)
+		time.Sleep(wait)
+		p.mu.Lock()
+	}
+	p.lastRestart = time.Now()
+	if p.t != nil {
+		p.t.Close()
+	}
+	p.mu.Unlock()
+
+	if err := p.Connect(); err != nil {
+		return err
+	}
+	return p.Authenticate()
+}
+
+// Close tears down the managed tor process, deleting its temp data dir
+// unless KeepDataDirOnClose was set.
+func (p *EmbeddedTorProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.t == nil {
+		return nil
+	}
+	return p.t.Close()
+}
@@ -0,0 +1,56 @@
+package main
+
+// Event is the marker type for every typed event a Provider can emit on
+// its SubscribeStreams channel. Consumers type-switch on the concrete
+// type (StreamEvent, StreamBWEvent, CircEvent, ...).
+type Event interface{}
+
+// StreamEvent is a "650 STREAM ..." status change (NEW, SUCCEEDED, CLOSED, ...).
+type StreamEvent struct {
+	ID        string
+	Status    string
+	Target    string
+	Reason    string
+	CircuitID string // circuit this stream is attached to, so it can be correlated with CircEvent
+}
+
+// StreamBWEvent is a "650 STREAM_BW ..." per-stream bandwidth sample.
+type StreamBWEvent struct {
+	ID            string
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// CircuitInfo describes a Tor circuit: the relays it's built through, how
+// long it took to build, and what it's for.
+type CircuitInfo struct {
+	ID          string   `json:"id"`
+	Status      string   `json:"status"`
+	Path        []string `json:"path"` // relay fingerprints/nicknames in path order
+	Purpose     string   `json:"purpose,omitempty"`
+	BuildTimeMs int64    `json:"build_time_ms,omitempty"`
+}
+
+// CircEvent is a "650 CIRC ..." circuit status event.
+type CircEvent struct {
+	Circuit CircuitInfo
+}
+
+// CircBWEvent is a "650 CIRC_BW ..." per-circuit bandwidth sample.
+type CircBWEvent struct {
+	ID            string
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// HSDescEvent is a "650 HS_DESC ..." hidden-service descriptor lookup event.
+type HSDescEvent struct {
+	Action  string // REQUESTED, RECEIVED, FAILED, ...
+	Address string
+}
+
+// ORConnEvent is a "650 ORCONN ..." OR connection (guard/relay link) status event.
+type ORConnEvent struct {
+	Target string
+	Status string
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// i2pRPCRequest/i2pRPCResponse are the JSON-RPC 2.0 envelopes used by the
+// I2PControl protocol (see https://geti2p.net/en/docs/api/i2pcontrol).
+type i2pRPCRequest struct {
+	ID      int                    `json:"id"`
+	Method  string                 `json:"method"`
+	Params  map[string]interface{} `json:"params"`
+	JSONRPC string                 `json:"jsonrpc"`
+}
+
+type i2pRPCResponse struct {
+	ID     int                    `json:"id"`
+	Result map[string]interface{} `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// I2PProvider speaks the I2PControl JSON-RPC protocol. I2PControl has no
+// push-style stream events, so instead of one event per real I2P tunnel it
+// polls router-wide tunnel/bandwidth counters on an interval and synthesizes
+// a single pseudo-stream per poll carrying the bytes moved since the last
+// poll. That keeps the rest of the monitor - which only understands lines
+// shaped like Tor's "650 STREAM ..." protocol - unchanged.
+type I2PProvider struct {
+	address  string // e.g. "127.0.0.1:7650"
+	password string
+	token    string
+
+	client     *http.Client
+	nextID     int
+	pollPeriod time.Duration
+	streamID   int
+}
+
+// NewI2PProvider builds an I2PProvider from its slice of the config.
+func NewI2PProvider(cfg ProviderConfig) *I2PProvider {
+	address := cfg.Address
+	if address == "" {
+		address = "127.0.0.1:7650"
+	}
+	return &I2PProvider{
+		address:  address,
+		password: cfg.Password,
+		client: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
+		pollPeriod: 15 * time.Second,
+	}
+}
+
+func (p *I2PProvider) Network() Network { return NetworkI2P }
+
+func (p *I2PProvider) call(method string, params map[string]interface{}) (map[string]interface{}, error) {
+	p.nextID++
+	body, err := json.Marshal(i2pRPCRequest{ID: p.nextID, Method: method, Params: params, JSONRPC: "2.0"})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Post("https://"+p.address+"/", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var rpcResp i2pRPCResponse
+	if err := json.Unmarshal(raw, &rpcResp); err != nil {
+		return nil, fmt.Errorf("malformed I2PControl response: %v", err)
+	}
+	if rpcResp.Error != nil {
+		return nil, fmt.Errorf("I2PControl error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+	}
+	return rpcResp.Result, nil
+}
+
+// Connect is a no-op beyond readiness of the HTTP client; I2PControl is
+// request/response over HTTPS, not a persistent session.
+func (p *I2PProvider) Connect() error {
+	fmt.Printf("%sUsing I2PControl endpoint https://%s%s\n", ColorCyan, p.address, ColorReset)
+	return nil
+}
+
+// Authenticate performs the I2PControl "Authenticate" RPC and stores the
+// returned session token, which subsequent calls must include as "Token".
+func (p *I2PProvider) Authenticate() error {
+	result, err := p.call("Authenticate", map[string]interface{}{
+		"API":      1,
+		"Password": p.password,
+	})
+	if err != nil {
+		return fmt.Errorf("I2P authentication failed: %v", err)
+	}
+
+	token, _ := result["Token"].(string)
+	if token == "" {
+		return fmt.Errorf("I2P authentication did not return a token")
+	}
+	p.token = token
+
+	fmt.Printf("%s✓ Successfully authenticated with I2PControl on %s%s\n", ColorGreen, p.address, ColorReset)
+	return nil
+}
+
+// SubscribeStreams polls NetworkSetting for bandwidth counters and emits a
+// synthetic stream lifecycle (NEW, bandwidth sample, CLOSED) per poll,
+// using the same typed events the Tor provider produces.
+func (p *I2PProvider) SubscribeStreams() (<-chan Event, error) {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(p.pollPeriod)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			result, err := p.call("NetworkSetting", map[string]interface{}{
+				"Token":                          p.token,
+				"i2p.router.net.bw.inbound.15s":  nil,
+				"i2p.router.net.bw.outbound.15s": nil,
+			})
+			if err != nil {
+				fmt.Printf("%sError polling I2PControl: %v%s\n", ColorRed, err, ColorReset)
+				return
+			}
+
+			// These stats are Bps rates averaged over the trailing 15s, not
+			// cumulative counters, so convert to a byte count for this poll
+			// by multiplying by the poll interval rather than passing the
+			// rate straight through as BytesSent/BytesReceived.
+			inRate, _ := toInt64(result["i2p.router.net.bw.inbound.15s"])
+			outRate, _ := toInt64(result["i2p.router.net.bw.outbound.15s"])
+			in := int64(float64(inRate) * p.pollPeriod.Seconds())
+			out := int64(float64(outRate) * p.pollPeriod.Seconds())
+
+			p.streamID++
+			id := strconv.Itoa(p.streamID)
+
+			events <- StreamEvent{ID: id, Status: "NEW", Target: "i2p-router:0"}
+			events <- StreamBWEvent{ID: id, BytesSent: out, BytesReceived: in}
+			events <- StreamEvent{ID: id, Status: "CLOSED", Target: "i2p-router:0", Reason: "DONE"}
+		}
+	}()
+
+	return events, nil
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int64(n), true
+	case int64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+func (p *I2PProvider) Close() error {
+	return nil
+}
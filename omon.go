@@ -1,15 +1,12 @@
 package main
 
 import (
-	"bufio"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"os"
-	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -26,16 +23,33 @@ const (
 	ColorBlue   = "\033[34m"
 )
 
+// ProviderConfig configures a single entry of the "providers" section of
+// omon.json. Which fields matter depends on Type: "tor" uses CookiePaths
+// and Ports, "i2p" uses Address and Password/Token.
+type ProviderConfig struct {
+	Type        string             `json:"type"`
+	CookiePaths []string           `json:"cookie_paths,omitempty"`
+	Ports       []string           `json:"ports,omitempty"`
+	Address     string             `json:"address,omitempty"`
+	Password    string             `json:"password,omitempty"`
+	EmbedTor    *EmbeddedTorConfig `json:"embed_tor,omitempty"`
+}
+
 // Config structure for omon.json
 type OmonConfig struct {
-	CookiePaths []string `json:"cookie_paths"`
-	Ports       []string `json:"ports"`
+	CookiePaths []string         `json:"cookie_paths"`
+	Ports       []string         `json:"ports"`
+	Providers   []ProviderConfig `json:"providers,omitempty"`
+	Metrics     MetricsConfig    `json:"metrics,omitempty"`
 }
 
-// StreamInfo tracks metadata and traffic for a single Tor stream
+// StreamInfo tracks metadata and traffic for a single stream on any
+// configured network.
 type StreamInfo struct {
 	ID            string    `json:"id"`
+	Network       Network   `json:"network"`
 	Target        string    `json:"target"`
+	CircuitID     string    `json:"circuit_id,omitempty"` // which circuit this stream flowed over, for Tor providers
 	BytesSent     int64     `json:"bytes_sent"`
 	BytesReceived int64     `json:"bytes_received"`
 	StartTime     time.Time `json:"start_time"`
@@ -43,28 +57,49 @@ type StreamInfo struct {
 	Closed        bool      `json:"closed"`
 }
 
-// TorMonitor handles the connection to Tor Control Port and stats aggregation
-type TorMonitor struct {
-	address       string
-	conn          net.Conn
-	reader        *bufio.Reader
-	streams       map[string]*StreamInfo
-	mu            sync.Mutex
-	logger        *log.Logger
-	stats         map[string]int64
-	report        map[string]int64
-	activeStreams int
-	totalStreams  int
+// TargetStats aggregates everything finalizeStream has observed about a
+// single target across every stream that has ever reached it, keyed by its
+// normalized host:port in Monitor.targetAggregates. It is what turns the
+// rolling per-stream log into a traffic-analysis summary.
+type TargetStats struct {
+	Target        string         `json:"target"`
+	Count         int            `json:"count"`
+	BytesSent     int64          `json:"bytes_sent"`
+	BytesReceived int64          `json:"bytes_received"`
+	FirstSeen     time.Time      `json:"first_seen"`
+	LastSeen      time.Time      `json:"last_seen"`
+	Failures      map[string]int `json:"failures,omitempty"`
+	Country       string         `json:"country,omitempty"` // two-letter code from GeoIPResolver, if the provider supports it
 }
 
-// NewTorMonitor initializes the monitor struct
-func NewTorMonitor(addr string, trafficLog *os.File) *TorMonitor {
-	return &TorMonitor{
-		address: addr,
-		streams: make(map[string]*StreamInfo),
-		logger:  log.New(trafficLog, "", log.LstdFlags),
-		stats:   make(map[string]int64),
-		report:  make(map[string]int64),
+// Monitor multiplexes one or more Providers (Tor, I2P, ...) into a single
+// streams map and log file. It used to be Tor-specific (TorMonitor); the
+// provider abstraction let it grow to cover other overlay networks.
+type Monitor struct {
+	providers        []Provider
+	streams          map[string]*StreamInfo
+	mu               sync.Mutex
+	logger           *log.Logger
+	stats            map[string]int64
+	report           map[string]int64
+	activeStreams    int
+	totalStreams     int
+	bootstrapPercent map[Network]int
+	metrics          *metricsRegistry
+	targetAggregates map[string]*TargetStats
+}
+
+// NewMonitor initializes the monitor struct with its configured providers.
+func NewMonitor(providers []Provider, trafficLog *os.File) *Monitor {
+	return &Monitor{
+		providers:        providers,
+		streams:          make(map[string]*StreamInfo),
+		logger:           log.New(trafficLog, "", log.LstdFlags),
+		stats:            make(map[string]int64),
+		report:           make(map[string]int64),
+		bootstrapPercent: make(map[Network]int),
+		metrics:          newMetricsRegistry(),
+		targetAggregates: make(map[string]*TargetStats),
 	}
 }
 
@@ -124,7 +159,7 @@ func loadConfig() (*OmonConfig, error) {
 		return nil, fmt.Errorf("failed to parse config: %v", err)
 	}
 
-	if config.CookiePaths == nil || len(config.CookiePaths) == 0 {
+	if len(config.Providers) == 0 && (config.CookiePaths == nil || len(config.CookiePaths) == 0) {
 		return nil, fmt.Errorf("'cookie_paths' array is empty or missing in config")
 	}
 
@@ -135,7 +170,7 @@ func loadConfig() (*OmonConfig, error) {
 			filteredPorts = append(filteredPorts, p)
 		}
 	}
-	if len(filteredPorts) == 0 {
+	if len(filteredPorts) == 0 && len(config.Providers) == 0 {
 		filteredPorts = []string{"9051"}
 		fmt.Printf("%sNo valid ports found; using default port 9051%s\n", ColorYellow, ColorReset)
 	}
@@ -144,179 +179,174 @@ func loadConfig() (*OmonConfig, error) {
 	return &config, nil
 }
 
-// findCookieFile searches for cookie file using user-defined paths from omon.json
-func findCookieFile() (string, error) {
-	config, err := loadConfig()
-	if err != nil {
-		return "", fmt.Errorf("config error: %v", err)
-	}
-
-	fmt.Printf("%sSearching for cookie file in configured paths...%s\n", ColorCyan, ColorReset)
-
-	for i, path := range config.CookiePaths {
-		expandedPath := os.ExpandEnv(path)
-
-		if _, err := os.Stat(expandedPath); err == nil {
-			fmt.Printf("%s✓ Found cookie file at path #%d: %s%s\n", ColorGreen, i+1, expandedPath, ColorReset)
-			return expandedPath, nil
-		}
-
-		if !filepath.IsAbs(expandedPath) {
-			absPath, err := filepath.Abs(expandedPath)
-			if err == nil {
-				if _, err := os.Stat(absPath); err == nil {
-					fmt.Printf("%s✓ Found cookie file at relative path #%d: %s%s\n", ColorGreen, i+1, absPath, ColorReset)
-					return absPath, nil
-				}
-			}
+// buildProviders turns the config's "providers" section into concrete
+// Provider instances. If no "providers" entry is present, it falls back to
+// a single Tor provider built from the legacy top-level cookie_paths/ports
+// fields so existing omon.json files keep working unchanged.
+func buildProviders(config *OmonConfig) ([]Provider, error) {
+	if len(config.Providers) == 0 {
+		return []Provider{NewTorProvider(ProviderConfig{
+			Type:        string(NetworkTor),
+			CookiePaths: config.CookiePaths,
+			Ports:       config.Ports,
+		})}, nil
+	}
+
+	providers := make([]Provider, 0, len(config.Providers))
+	for _, cfg := range config.Providers {
+		p, err := newProvider(cfg)
+		if err != nil {
+			return nil, err
 		}
-
-		fmt.Printf("%s✗ Path #%d not found: %s%s\n", ColorGray, i+1, expandedPath, ColorReset)
+		providers = append(providers, p)
 	}
-
-	return "", fmt.Errorf("cookie file not found in any configured path. Check your omon.json file")
+	return providers, nil
 }
 
-// tryConnect attempts to connect to Tor on a specific address
-func tryConnect(address string) (net.Conn, error) {
-	conn, err := net.DialTimeout("tcp", address, 3*time.Second)
-	if err != nil {
-		return nil, err
-	}
-	return conn, nil
-}
-
-// connectToTor attempts to connect to Tor on multiple ports (excluding 9151)
-func connectToTor() (net.Conn, string, error) {
-	config, err := loadConfig()
-	if err != nil {
-		return nil, "", fmt.Errorf("config error: %v", err)
-	}
-
-	fmt.Printf("%sTrying to connect to Tor control port...%s\n", ColorCyan, ColorReset)
-
-	for _, port := range config.Ports {
-		address := fmt.Sprintf("127.0.0.1:%s", port)
-		fmt.Printf("  Trying port %s... ", port)
-
-		conn, err := tryConnect(address)
-		if err == nil {
-			fmt.Printf("%s✓ Connected%s\n", ColorGreen, ColorReset)
-			return conn, address, nil
+// Start connects every configured provider, authenticates, and begins
+// monitoring its stream events.
+func (m *Monitor) Start() error {
+	for _, p := range m.providers {
+		if err := m.connectProvider(p); err != nil {
+			return err
 		}
+	}
 
-		fmt.Printf("%s✗ Failed%s\n", ColorRed, ColorReset)
+	fmt.Printf("%s✓ Onion Monitor Started.%s\n", ColorCyan, ColorReset)
+	fmt.Printf("%sLogging all events to file...%s\n", ColorCyan, ColorReset)
 
-		address = fmt.Sprintf("localhost:%s", port)
-		conn, err = tryConnect(address)
-		if err == nil {
-			fmt.Printf("  localhost:%s %s✓ Connected%s\n", port, ColorGreen, ColorReset)
-			return conn, address, nil
-		}
-	}
+	go m.periodicStats()
 
-	return nil, "", fmt.Errorf("could not connect to Tor on any configured port: %v", config.Ports)
+	return nil
 }
 
-// Start connects to Tor, authenticates, and begins event monitoring
-func (m *TorMonitor) Start() error {
-	var err error
-
-	m.conn, m.address, err = connectToTor()
-	if err != nil {
-		return fmt.Errorf("failed to connect to Tor control port: %v", err)
+// connectProvider performs the one-time connect/authenticate/subscribe
+// sequence for a provider and hands its event channel off to runProvider.
+func (m *Monitor) connectProvider(p Provider) error {
+	if err := p.Connect(); err != nil {
+		return fmt.Errorf("%s: failed to connect: %v", p.Network(), err)
 	}
 
-	m.reader = bufio.NewReader(m.conn)
-
-	path, err := findCookieFile()
-	if err != nil {
-		return fmt.Errorf("authentication error: %v", err)
+	if err := p.Authenticate(); err != nil {
+		return fmt.Errorf("%s: authentication error: %v", p.Network(), err)
 	}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("failed to read cookie file: %v", err)
+	if sr, ok := p.(StatusReporter); ok {
+		network := p.Network()
+		sr.SetStatusCallback(func(percent int, summary string) {
+			m.mu.Lock()
+			m.bootstrapPercent[network] = percent
+			m.mu.Unlock()
+		})
 	}
 
-	authCmd := fmt.Sprintf("AUTHENTICATE %s\r\n", hex.EncodeToString(data))
-	fmt.Fprintf(m.conn, authCmd)
-
-	response, err := m.reader.ReadString('\n')
+	lines, err := p.SubscribeStreams()
 	if err != nil {
-		return fmt.Errorf("failed to read authentication response: %v", err)
-	}
-
-	if !strings.Contains(response, "250 OK") {
-		return fmt.Errorf("authentication failed: %s", strings.TrimSpace(response))
+		return fmt.Errorf("%s: failed to subscribe to stream events: %v", p.Network(), err)
 	}
 
-	fmt.Printf("%s✓ Successfully authenticated with Tor on %s%s\n", ColorGreen, m.address, ColorReset)
+	// Start draining lines before blocking on bootstrap: CIRC/ORCONN/HS_DESC
+	// events (which TorProvider forwards on this same channel) can arrive
+	// before bootstrap reaches 100%, and nothing else reads this channel -
+	// waiting on WaitTillBootstrapped first would deadlock against them.
+	go m.runProvider(p, lines)
 
-	fmt.Fprintf(m.conn, "SETEVENTS STREAM STREAM_BW\r\n")
-	m.reader.ReadString('\n')
-
-	fmt.Printf("%s✓ Onion Monitor Started.%s\n", ColorCyan, ColorReset)
-	fmt.Printf("%sLogging all events to file...%s\n", ColorCyan, ColorReset)
-
-	go m.eventLoop()
-	go m.periodicStats()
+	if sr, ok := p.(StatusReporter); ok {
+		fmt.Printf("%sWaiting for %s to finish bootstrapping...%s\n", ColorCyan, p.Network(), ColorReset)
+		sr.WaitTillBootstrapped()
+	}
 
 	return nil
 }
 
-// eventLoop reads the raw stream from Tor Control Port
-func (m *TorMonitor) eventLoop() {
+// runProvider drains a provider's event channel until it closes (signaling
+// a lost connection), then restarts and resubscribes providers that
+// support it (Restarter). Providers without that support simply stop
+// being monitored once their connection drops, matching prior behavior.
+func (m *Monitor) runProvider(p Provider, lines <-chan Event) {
 	for {
-		line, err := m.reader.ReadString('\n')
+		m.eventLoop(p.Network(), lines)
+
+		restarter, ok := p.(Restarter)
+		if !ok {
+			return
+		}
+
+		fmt.Printf("%s%s connection lost; attempting restart...%s\n", ColorYellow, p.Network(), ColorReset)
+		if err := restarter.Restart(); err != nil {
+			fmt.Printf("%sFailed to restart %s provider: %v%s\n", ColorRed, p.Network(), err, ColorReset)
+			return
+		}
+
+		newLines, err := p.SubscribeStreams()
 		if err != nil {
-			fmt.Printf("%sError reading from Tor control port: %v%s\n", ColorRed, err, ColorReset)
+			fmt.Printf("%sFailed to resubscribe %s provider after restart: %v%s\n", ColorRed, p.Network(), err, ColorReset)
 			return
 		}
-		line = strings.TrimSpace(line)
+		lines = newLines
+	}
+}
 
-		if strings.HasPrefix(line, "650 STREAM ") && !strings.Contains(line, "STREAM_BW") {
-			m.handleStreamEvent(line)
-		} else if strings.HasPrefix(line, "650 STREAM_BW") {
-			m.handleStreamBWEvent(line)
+// eventLoop dispatches typed events from a single provider.
+func (m *Monitor) eventLoop(network Network, events <-chan Event) {
+	for ev := range events {
+		switch e := ev.(type) {
+		case StreamEvent:
+			m.handleStreamEvent(network, e)
+		case StreamBWEvent:
+			m.handleStreamBWEvent(network, e)
+		case CircEvent:
+			m.handleCircEvent(network, e)
+		case CircBWEvent:
+			// Per-circuit bandwidth isn't surfaced on its own today; the
+			// circuit's cumulative path/purpose/build-time is what shows
+			// up in reports, via CircEvent.
+		case HSDescEvent:
+			m.handleHSDescEvent(network, e)
+		case ORConnEvent:
+			m.handleORConnEvent(network, e)
 		}
 	}
 }
 
+// streamKey namespaces a provider's stream id by network, since Tor and
+// I2P hand out ids from independent counters and would otherwise collide
+// in the shared streams map.
+func streamKey(network Network, id string) string {
+	return string(network) + ":" + id
+}
+
 // handleStreamEvent processes status changes (NEW, SUCCEEDED, CLOSED, etc.)
-func (m *TorMonitor) handleStreamEvent(line string) {
+func (m *Monitor) handleStreamEvent(network Network, e StreamEvent) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	parts := strings.Fields(line)
-	if len(parts) < 4 {
-		return
-	}
-
-	id, status := parts[2], parts[3]
+	key := streamKey(network, e.ID)
 
-	if _, exists := m.streams[id]; !exists {
-		m.streams[id] = &StreamInfo{
-			ID:        id,
+	if _, exists := m.streams[key]; !exists {
+		m.streams[key] = &StreamInfo{
+			ID:        e.ID,
+			Network:   network,
 			StartTime: time.Now(),
 		}
 		m.stats["streams_total"]++
 		m.report["streams_total"]++
 		m.activeStreams++
 		m.totalStreams++
+		m.metrics.streamsTotal.Inc()
+		m.metrics.streamsActive.Inc()
 	}
 
-	s := m.streams[id]
-
-	for i := 4; i < len(parts); i++ {
-		p := parts[i]
-		if !strings.Contains(p, "=") && p != "-" {
-			s.Target = p
-		}
+	s := m.streams[key]
+	if e.Target != "" {
+		s.Target = e.Target
+	}
+	if e.CircuitID != "" && e.CircuitID != "0" {
+		s.CircuitID = e.CircuitID
 	}
 
 	color := ColorGray
-	switch status {
+	switch e.Status {
 	case "SUCCEEDED":
 		color = ColorGreen
 	case "FAILED":
@@ -328,68 +358,83 @@ func (m *TorMonitor) handleStreamEvent(line string) {
 	}
 
 	// Console output WITHOUT any process/PID info
-	fmt.Printf("[%s] Stream %s %s%s%s | Target: %s\n",
-		time.Now().Format("15:04:05"), id, color, status, ColorReset, s.Target)
+	fmt.Printf("[%s] [%s] Stream %s %s%s%s | Target: %s\n",
+		time.Now().Format("15:04:05"), network, e.ID, color, e.Status, ColorReset, s.Target)
 
 	// Log to file (same simplified format)
-	m.logger.Printf("Stream %s %s | Target: %s", id, status, s.Target)
+	m.logger.Printf("[%s] Stream %s %s | Target: %s", network, e.ID, e.Status, s.Target)
 
-	if status == "CLOSED" || status == "FAILED" {
+	if e.Status == "CLOSED" || e.Status == "FAILED" {
 		if !s.Closed {
 			s.Closed = true
 			s.EndTime = time.Now()
 			m.activeStreams--
+			m.metrics.streamsActive.Dec()
 
-			reason := "NONE"
-			for _, p := range parts {
-				if strings.HasPrefix(p, "REASON=") {
-					reason = strings.TrimPrefix(p, "REASON=")
-				}
+			reason := e.Reason
+			if reason == "" {
+				reason = "NONE"
 			}
 
 			m.finalizeStream(s, reason)
-			delete(m.streams, id)
+			delete(m.streams, key)
 		}
 	}
 }
 
 // handleStreamBWEvent accumulates byte counts
-func (m *TorMonitor) handleStreamBWEvent(line string) {
+func (m *Monitor) handleStreamBWEvent(network Network, e StreamBWEvent) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	parts := strings.Fields(line)
-	if len(parts) < 5 {
-		return
-	}
-
-	id := parts[2]
-	sent, _ := strconv.ParseInt(parts[3], 10, 64)
-	rcvd, _ := strconv.ParseInt(parts[4], 10, 64)
+	if s, exists := m.streams[streamKey(network, e.ID)]; exists {
+		s.BytesSent += e.BytesSent
+		s.BytesReceived += e.BytesReceived
 
-	if s, exists := m.streams[id]; exists {
-		s.BytesSent += sent
-		s.BytesReceived += rcvd
+		m.stats["total_sent"] += e.BytesSent
+		m.stats["total_received"] += e.BytesReceived
 
-		m.stats["total_sent"] += sent
-		m.stats["total_received"] += rcvd
+		m.report["total_sent"] += e.BytesSent
+		m.report["total_received"] += e.BytesReceived
 
-		m.report["total_sent"] += sent
-		m.report["total_received"] += rcvd
+		m.metrics.bytesSentTotal.Add(float64(e.BytesSent))
+		m.metrics.bytesRecvTotal.Add(float64(e.BytesReceived))
 	}
 }
 
+// handleCircEvent logs a Tor circuit status change.
+func (m *Monitor) handleCircEvent(network Network, e CircEvent) {
+	m.logger.Printf("[%s] Circuit %s %s | Path: %s | Purpose: %s",
+		network, e.Circuit.ID, e.Circuit.Status, strings.Join(e.Circuit.Path, " -> "), e.Circuit.Purpose)
+}
+
+// handleHSDescEvent logs a hidden-service descriptor lookup.
+func (m *Monitor) handleHSDescEvent(network Network, e HSDescEvent) {
+	m.logger.Printf("[%s] HS_DESC %s %s", network, e.Action, e.Address)
+}
+
+// handleORConnEvent logs an OR connection (guard/relay link) status change.
+func (m *Monitor) handleORConnEvent(network Network, e ORConnEvent) {
+	m.logger.Printf("[%s] ORCONN %s %s", network, e.Target, e.Status)
+}
+
 // finalizeStream logs final stream results and global totals
-func (m *TorMonitor) finalizeStream(s *StreamInfo, reason string) {
+func (m *Monitor) finalizeStream(s *StreamInfo, reason string) {
 	duration := time.Since(s.StartTime).Round(time.Millisecond)
 
+	m.metrics.streamDuration.Observe(duration.Seconds())
+	m.metrics.streamBytes.WithLabelValues("sent").Observe(float64(s.BytesSent))
+	m.metrics.streamBytes.WithLabelValues("received").Observe(float64(s.BytesReceived))
+
+	m.recordTargetStats(s, reason)
+
 	displayReason := reason
 	if reason == "DONE" {
 		displayReason = "END"
 	}
 
-	summary := fmt.Sprintf("Stream %s FINISHED: S:%d R:%d bytes | %v | To: %s (%s)",
-		s.ID, s.BytesSent, s.BytesReceived, duration, s.Target, displayReason)
+	summary := fmt.Sprintf("[%s] Stream %s FINISHED: S:%d R:%d bytes | %v | To: %s (%s)",
+		s.Network, s.ID, s.BytesSent, s.BytesReceived, duration, s.Target, displayReason)
 
 	accMsg := fmt.Sprintf("Total now: S:%d R:%d bytes | All Streams: %d | Active: %d",
 		m.stats["total_sent"], m.stats["total_received"], m.totalStreams, m.activeStreams)
@@ -401,8 +446,156 @@ func (m *TorMonitor) finalizeStream(s *StreamInfo, reason string) {
 	m.logger.Println(accMsg)
 }
 
+// normalizeTarget canonicalizes a stream target for use as a
+// targetAggregates key, so "Example.com:443" and "example.com:443" don't
+// get tracked as two different targets.
+func normalizeTarget(target string) string {
+	return strings.ToLower(strings.TrimSpace(target))
+}
+
+// hostOnly strips a trailing ":port" from a normalized target, if present,
+// so the bare host can be checked against net.ParseIP for a GeoIP lookup.
+func hostOnly(target string) string {
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		return target
+	}
+	return host
+}
+
+// recordTargetStats folds a finished stream into its target's running
+// aggregate and, if the target is a bare IP and a provider can resolve it,
+// kicks off a GeoIP lookup in the background. Called with m.mu held.
+func (m *Monitor) recordTargetStats(s *StreamInfo, reason string) {
+	key := normalizeTarget(s.Target)
+	if key == "" {
+		return
+	}
+
+	agg, ok := m.targetAggregates[key]
+	if !ok {
+		agg = &TargetStats{Target: key, FirstSeen: s.StartTime, Failures: make(map[string]int)}
+		m.targetAggregates[key] = agg
+	}
+	agg.Count++
+	agg.BytesSent += s.BytesSent
+	agg.BytesReceived += s.BytesReceived
+	agg.LastSeen = s.EndTime
+	if reason != "" && reason != "NONE" && reason != "DONE" {
+		agg.Failures[reason]++
+	}
+
+	if agg.Country == "" {
+		if ip := hostOnly(key); net.ParseIP(ip) != nil {
+			go m.resolveCountry(key, ip)
+		}
+	}
+}
+
+// resolveCountry looks up ip's country code through whichever configured
+// provider implements GeoIPResolver (only Tor does today) and records it on
+// the target's aggregate. Runs outside m.mu so the control-port round trip
+// never blocks the event loop.
+func (m *Monitor) resolveCountry(key, ip string) {
+	var resolver GeoIPResolver
+	for _, p := range m.providers {
+		if r, ok := p.(GeoIPResolver); ok {
+			resolver = r
+			break
+		}
+	}
+	if resolver == nil {
+		return
+	}
+
+	cc, err := resolver.CountryForIP(ip)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	if agg, ok := m.targetAggregates[key]; ok {
+		agg.Country = cc
+	}
+	m.mu.Unlock()
+}
+
+// dumpTargets writes a snapshot of the current per-target aggregates to
+// path as JSON, for offline traffic analysis (see setupDumpSignal).
+func (m *Monitor) dumpTargets(path string) error {
+	m.mu.Lock()
+	// Copy by value (and clone Failures) while still holding mu: these
+	// fields are mutated under the same lock in recordTargetStats, and a
+	// pointer/shared-map snapshot taken here would let MarshalIndent race
+	// with that.
+	snapshot := make([]TargetStats, 0, len(m.targetAggregates))
+	for _, agg := range m.targetAggregates {
+		copyAgg := *agg
+		copyAgg.Failures = make(map[string]int, len(agg.Failures))
+		for reason, count := range agg.Failures {
+			copyAgg.Failures[reason] = count
+		}
+		snapshot = append(snapshot, copyAgg)
+	}
+	m.mu.Unlock()
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+const topTargetsN = 5
+
+// totalFailures sums a target's failure-reason histogram into one count.
+func totalFailures(agg *TargetStats) int {
+	n := 0
+	for _, count := range agg.Failures {
+		n += count
+	}
+	return n
+}
+
+// printTopTargets logs the most-contacted, largest-transfer and
+// highest-failure targets seen since startup. Called with m.mu held.
+func (m *Monitor) printTopTargets() {
+	if len(m.targetAggregates) == 0 {
+		return
+	}
+
+	all := make([]*TargetStats, 0, len(m.targetAggregates))
+	for _, agg := range m.targetAggregates {
+		all = append(all, agg)
+	}
+
+	printTop := func(title string, value func(*TargetStats) int64) {
+		ranked := append([]*TargetStats(nil), all...)
+		sort.Slice(ranked, func(i, j int) bool { return value(ranked[i]) > value(ranked[j]) })
+		if len(ranked) > topTargetsN {
+			ranked = ranked[:topTargetsN]
+		}
+
+		fmt.Printf("%s--- %s ---%s\n", ColorGreen, title, ColorReset)
+		for _, agg := range ranked {
+			country := agg.Country
+			if country == "" {
+				country = "??"
+			}
+			line := fmt.Sprintf("  %-40s [%s] streams:%-4d sent:%d recv:%d failures:%d",
+				agg.Target, country, agg.Count, agg.BytesSent, agg.BytesReceived, totalFailures(agg))
+			fmt.Println(line)
+			m.logger.Println(line)
+		}
+	}
+
+	printTop("Top targets by stream count", func(a *TargetStats) int64 { return int64(a.Count) })
+	printTop("Top targets by bytes transferred", func(a *TargetStats) int64 { return a.BytesSent + a.BytesReceived })
+	printTop("Top targets by failure count", func(a *TargetStats) int64 { return int64(totalFailures(a)) })
+}
+
 // periodicStats prints a summary every 10 minutes and resets report counters
-func (m *TorMonitor) periodicStats() {
+func (m *Monitor) periodicStats() {
 	ticker := time.NewTicker(10 * time.Minute)
 
 	for {
@@ -417,9 +610,32 @@ func (m *TorMonitor) periodicStats() {
 				sentMB, m.report["total_sent"], receivedMB, m.report["total_received"],
 				m.report["streams_total"], m.activeStreams)
 
-			fmt.Printf("\n%s%s%s\n\n", ColorGreen, report, ColorReset)
+			fmt.Printf("\n%s%s%s\n", ColorGreen, report, ColorReset)
 			m.logger.Println(report)
 
+			for network, percent := range m.bootstrapPercent {
+				bootstrapLine := fmt.Sprintf("--- %s bootstrap: %d%% ---", network, percent)
+				fmt.Printf("%s%s%s\n", ColorGreen, bootstrapLine, ColorReset)
+				m.logger.Println(bootstrapLine)
+			}
+
+			for _, p := range m.providers {
+				cr, ok := p.(CircuitReporter)
+				if !ok {
+					continue
+				}
+				circuits := cr.Circuits()
+				circuitLine := fmt.Sprintf("--- %s circuits: %d known ---", p.Network(), len(circuits))
+				fmt.Printf("%s%s%s\n", ColorGreen, circuitLine, ColorReset)
+				m.logger.Println(circuitLine)
+				for _, c := range circuits {
+					m.logger.Printf("  Circuit %s %s | Path: %s | Purpose: %s | Build: %dms",
+						c.ID, c.Status, strings.Join(c.Path, " -> "), c.Purpose, c.BuildTimeMs)
+				}
+			}
+			m.printTopTargets()
+			fmt.Println()
+
 			m.report["total_sent"] = 0
 			m.report["total_received"] = 0
 			m.report["streams_total"] = 0
@@ -467,7 +683,24 @@ func main() {
 	}
 	defer logF.Close()
 
-	monitor := NewTorMonitor("", logF)
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Printf("\n%sStartup Error: %v%s\n", ColorRed, err, ColorReset)
+		os.Exit(1)
+	}
+
+	providers, err := buildProviders(config)
+	if err != nil {
+		fmt.Printf("\n%sStartup Error: %v%s\n", ColorRed, err, ColorReset)
+		os.Exit(1)
+	}
+
+	monitor := NewMonitor(providers, logF)
+	monitor.setupDumpSignal()
+	if err := monitor.startMetricsServer(config.Metrics); err != nil {
+		fmt.Printf("\n%sStartup Error: %v%s\n", ColorRed, err, ColorReset)
+		os.Exit(1)
+	}
 	if err := monitor.Start(); err != nil {
 		fmt.Printf("\n%sStartup Error: %v%s\n", ColorRed, err, ColorReset)
 		fmt.Printf("\n%sTroubleshooting tips:%s\n", ColorYellow, ColorReset)
@@ -480,7 +713,6 @@ func main() {
 	}
 
 	fmt.Printf("\n%sMonitoring active. Press Ctrl+C to exit.%s\n", ColorGreen, ColorReset)
-	fmt.Printf("%sConnected to: %s%s\n", ColorGray, monitor.address, ColorReset)
 	fmt.Printf("%sLog file: %s%s\n", ColorGray, logFileName, ColorReset)
 	fmt.Printf("%sProcess detection: Disabled (as requested)%s\n", ColorGray, ColorReset)
 
@@ -0,0 +1,94 @@
+package main
+
+import "fmt"
+
+// Network identifies which anonymity network a stream was observed on.
+type Network string
+
+const (
+	NetworkTor Network = "tor"
+	NetworkI2P Network = "i2p"
+)
+
+// Provider abstracts a backend anonymity-network control connection so the
+// monitor's event loop can treat every configured network identically once
+// it is connected and authenticated. TorProvider and I2PProvider are the
+// two implementations today; a new overlay network only needs to satisfy
+// this interface to be multiplexed into the same streams map.
+type Provider interface {
+	// Network returns the identifier used to tag streams from this provider.
+	Network() Network
+
+	// Connect establishes the underlying transport to the control service.
+	Connect() error
+
+	// Authenticate proves identity to the control service using whatever
+	// auth material the provider was configured with (cookie, token, etc.).
+	Authenticate() error
+
+	// SubscribeStreams starts delivering typed stream lifecycle and
+	// bandwidth events (StreamEvent, StreamBWEvent). The channel is closed
+	// when the underlying connection is lost.
+	SubscribeStreams() (<-chan Event, error)
+
+	// Close tears down the connection to the control service.
+	Close() error
+}
+
+// StatusReporter is implemented by providers that expose network-wide
+// bootstrap/liveness status in addition to per-stream events. Tor is the
+// only provider that has this concept today; I2P has no equivalent and
+// does not implement it.
+type StatusReporter interface {
+	// SetStatusCallback registers a function invoked on every bootstrap
+	// phase change with the current percentage and Tor's own summary text.
+	SetStatusCallback(func(percent int, summary string))
+
+	// WaitTillBootstrapped blocks until the provider reports 100% bootstrap.
+	WaitTillBootstrapped()
+
+	// BootstrapPercent returns the most recently observed bootstrap percentage.
+	BootstrapPercent() int
+}
+
+// Restarter is implemented by providers that can recover from a lost
+// connection without the whole process restarting, such as EmbeddedTorProvider
+// restarting its managed tor child.
+type Restarter interface {
+	// Restart re-establishes the provider's connection, respecting
+	// whatever internal cooldown the provider enforces between attempts.
+	Restart() error
+}
+
+// CircuitReporter is implemented by providers that can correlate streams
+// with the circuits they flow over. Only Tor has circuits; I2P does not
+// implement it.
+type CircuitReporter interface {
+	// Circuits returns a snapshot of all circuits observed so far, keyed by ID.
+	Circuits() map[string]CircuitInfo
+}
+
+// GeoIPResolver is implemented by providers that can resolve an IP address
+// to a country code using the control service's own database. Only Tor
+// exposes GETINFO ip-to-country; I2P does not implement it.
+type GeoIPResolver interface {
+	// CountryForIP returns the two-letter country code for ip, or "??" if
+	// it could not be determined.
+	CountryForIP(ip string) (string, error)
+}
+
+// newProvider constructs the Provider for a single entry of the config's
+// "providers" section.
+func newProvider(cfg ProviderConfig) (Provider, error) {
+	switch cfg.Type {
+	case "", string(NetworkTor):
+		if cfg.EmbedTor != nil {
+			return NewEmbeddedTorProvider(*cfg.EmbedTor), nil
+		}
+		return NewTorProvider(cfg), nil
+	case string(NetworkI2P):
+		return NewI2PProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown provider type %q", cfg.Type)
+	}
+}
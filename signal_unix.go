@@ -0,0 +1,28 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// setupDumpSignal registers SIGUSR1 to flush the current target aggregates
+// to targets.json on demand, so an operator can pull a traffic-analysis
+// snapshot without stopping omon.
+func (m *Monitor) setupDumpSignal() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1)
+
+	go func() {
+		for range sigs {
+			if err := m.dumpTargets("targets.json"); err != nil {
+				fmt.Printf("%sFailed to dump targets.json: %v%s\n", ColorRed, err, ColorReset)
+				continue
+			}
+			fmt.Printf("%sDumped target aggregates to targets.json%s\n", ColorGreen, ColorReset)
+		}
+	}()
+}
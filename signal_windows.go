@@ -0,0 +1,9 @@
+//go:build windows
+
+package main
+
+// setupDumpSignal is a no-op on Windows: there is no SIGUSR1 equivalent,
+// and omon.json's default cookie paths show Windows as a supported
+// platform, so this simply leaves the dump-on-signal feature unavailable
+// there rather than failing to build.
+func (m *Monitor) setupDumpSignal() {}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TorProvider speaks the Tor control-port protocol and implements Provider.
+// It owns the cookie lookup, port-probing and control-connection handling
+// that used to live directly on TorMonitor; protocol parsing itself is
+// delegated to the Conn type in control.go.
+type TorProvider struct {
+	cookiePaths []string
+	ports       []string
+
+	ctrl    *Conn
+	address string
+
+	statusMu         sync.Mutex
+	bootstrapPercent int
+	networkLiveness  string // "up", "down", or "unknown"
+	statusCallback   func(percent int, summary string)
+	bootstrapped     chan struct{}
+	bootstrappedOnce sync.Once
+
+	circuitsMu  sync.Mutex
+	circuits    map[string]*CircuitInfo
+	circuitSeen map[string]time.Time // circuit ID -> LAUNCHED time, for build duration
+
+	countryMu    sync.Mutex
+	countryCache map[string]string // IP -> two-letter country code, from GETINFO ip-to-country
+}
+
+// NewTorProvider builds a TorProvider from its slice of the config.
+func NewTorProvider(cfg ProviderConfig) *TorProvider {
+	cookiePaths := cfg.CookiePaths
+	ports := cfg.Ports
+	if len(ports) == 0 {
+		ports = []string{"9051"}
+	}
+	return &TorProvider{
+		cookiePaths:     cookiePaths,
+		ports:           ports,
+		networkLiveness: "unknown",
+		bootstrapped:    make(chan struct{}),
+		circuits:        make(map[string]*CircuitInfo),
+		circuitSeen:     make(map[string]time.Time),
+		countryCache:    make(map[string]string),
+	}
+}
+
+func (p *TorProvider) Network() Network { return NetworkTor }
+
+// tryConnect attempts to connect to Tor on a specific address
+func tryConnect(address string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", address, 3*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Connect attempts to connect to Tor on any of the configured ports
+// (excluding 9151, Tor Browser's control port).
+func (p *TorProvider) Connect() error {
+	fmt.Printf("%sTrying to connect to Tor control port...%s\n", ColorCyan, ColorReset)
+
+	for _, port := range p.ports {
+		if port == "9151" {
+			continue
+		}
+
+		address := fmt.Sprintf("127.0.0.1:%s", port)
+		fmt.Printf("  Trying port %s... ", port)
+
+		conn, err := tryConnect(address)
+		if err == nil {
+			fmt.Printf("%s✓ Connected%s\n", ColorGreen, ColorReset)
+			p.ctrl, p.address = NewConn(conn), address
+			return nil
+		}
+
+		fmt.Printf("%s✗ Failed%s\n", ColorRed, ColorReset)
+
+		address = fmt.Sprintf("localhost:%s", port)
+		conn, err = tryConnect(address)
+		if err == nil {
+			fmt.Printf("  localhost:%s %s✓ Connected%s\n", port, ColorGreen, ColorReset)
+			p.ctrl, p.address = NewConn(conn), address
+			return nil
+		}
+	}
+
+	return fmt.Errorf("could not connect to Tor on any configured port: %v", p.ports)
+}
+
+// findCookieFile searches for cookie file using the provider's configured paths
+func (p *TorProvider) findCookieFile() (string, error) {
+	if len(p.cookiePaths) == 0 {
+		return "", fmt.Errorf("'cookie_paths' is empty or missing in config")
+	}
+
+	fmt.Printf("%sSearching for cookie file in configured paths...%s\n", ColorCyan, ColorReset)
+
+	for i, path := range p.cookiePaths {
+		expandedPath := os.ExpandEnv(path)
+
+		if _, err := os.Stat(expandedPath); err == nil {
+			fmt.Printf("%s✓ Found cookie file at path #%d: %s%s\n", ColorGreen, i+1, expandedPath, ColorReset)
+			return expandedPath, nil
+		}
+
+		if !filepath.IsAbs(expandedPath) {
+			absPath, err := filepath.Abs(expandedPath)
+			if err == nil {
+				if _, err := os.Stat(absPath); err == nil {
+					fmt.Printf("%s✓ Found cookie file at relative path #%d: %s%s\n", ColorGreen, i+1, absPath, ColorReset)
+					return absPath, nil
+				}
+			}
+		}
+
+		fmt.Printf("%s✗ Path #%d not found: %s%s\n", ColorGray, i+1, expandedPath, ColorReset)
+	}
+
+	return "", fmt.Errorf("cookie file not found in any configured path. Check your omon.json file")
+}
+
+// Authenticate reads the control-port auth cookie and authenticates.
+func (p *TorProvider) Authenticate() error {
+	path, err := p.findCookieFile()
+	if err != nil {
+		return fmt.Errorf("authentication error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read cookie file: %v", err)
+	}
+
+	if _, err := p.ctrl.SendCommand("AUTHENTICATE", hex.EncodeToString(data)); err != nil {
+		return fmt.Errorf("authentication failed: %v", err)
+	}
+
+	fmt.Printf("%s✓ Successfully authenticated with Tor on %s%s\n", ColorGreen, p.address, ColorReset)
+	return nil
+}
+
+// SubscribeStreams issues SETEVENTS (stream, bandwidth, circuit, hidden
+// service, OR-connection, and status events) and delivers typed events.
+// Bootstrap/liveness status lines are consumed here rather than forwarded,
+// since that state belongs to the provider, not the generic stream
+// handlers in Monitor.
+func (p *TorProvider) SubscribeStreams() (<-chan Event, error) {
+	if _, err := p.ctrl.SendCommand("SETEVENTS", "STREAM", "STREAM_BW", "CIRC", "CIRC_BW", "HS_DESC", "ORCONN", "STATUS_CLIENT", "NOTICE", "WARN"); err != nil {
+		return nil, fmt.Errorf("failed to SETEVENTS: %v", err)
+	}
+
+	if err := p.queryBootstrapPhase(); err != nil {
+		fmt.Printf("%sWarning: could not query bootstrap status: %v%s\n", ColorYellow, err, ColorReset)
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		// Conn's own readLoop (started in NewConn) is the connection's sole
+		// reader; it demuxes these "650 ..." lines away from SendCommand
+		// replies, so this just dispatches them.
+		for line := range p.ctrl.Events() {
+			p.dispatchEvent(line, events)
+		}
+	}()
+
+	return events, nil
+}
+
+// dispatchEvent parses a single "650 ..." line and, for stream/circuit/HS
+// events, forwards a typed Event. Status/liveness lines update provider
+// state directly instead of being forwarded.
+func (p *TorProvider) dispatchEvent(line string, events chan<- Event) {
+	fields := splitKeywords(line)
+	if len(fields) < 2 {
+		return
+	}
+	kind := fields[1]
+	rest := fields[2:]
+
+	switch kind {
+	case "STREAM":
+		if len(rest) < 2 {
+			return
+		}
+		// rest is [id, status, circID, target, ...]; circID is bare too, so
+		// take the last non-keyword token rather than the first.
+		circID := ""
+		if len(rest) > 2 {
+			circID = rest[2]
+		}
+		positional, kv := ParseKeywordArgs(rest[2:])
+		target := ""
+		if len(positional) > 0 {
+			target = positional[len(positional)-1]
+		}
+		events <- StreamEvent{ID: rest[0], Status: rest[1], Target: target, Reason: kv["REASON"], CircuitID: circID}
+
+	case "STREAM_BW":
+		if len(rest) < 3 {
+			return
+		}
+		sent, _ := strconv.ParseInt(rest[1], 10, 64)
+		rcvd, _ := strconv.ParseInt(rest[2], 10, 64)
+		events <- StreamBWEvent{ID: rest[0], BytesSent: sent, BytesReceived: rcvd}
+
+	case "CIRC":
+		if len(rest) < 2 {
+			return
+		}
+		events <- p.handleCircEvent(rest[0], rest[1], rest[2:])
+
+	case "CIRC_BW":
+		_, kv := ParseKeywordArgs(rest)
+		sent, _ := strconv.ParseInt(kv["WRITTEN"], 10, 64)
+		rcvd, _ := strconv.ParseInt(kv["READ"], 10, 64)
+		events <- CircBWEvent{ID: kv["ID"], BytesSent: sent, BytesReceived: rcvd}
+
+	case "HS_DESC":
+		if len(rest) < 2 {
+			return
+		}
+		events <- HSDescEvent{Action: rest[0], Address: rest[1]}
+
+	case "ORCONN":
+		if len(rest) < 2 {
+			return
+		}
+		events <- ORConnEvent{Target: rest[0], Status: rest[1]}
+
+	case "STATUS_CLIENT":
+		p.handleStatusClientEvent(line)
+	}
+}
+
+// handleCircEvent tracks circuit path/purpose/build-time as CIRC events
+// arrive and returns the CircEvent to forward to Monitor.
+func (p *TorProvider) handleCircEvent(id, status string, rest []string) CircEvent {
+	positional, kv := ParseKeywordArgs(rest)
+
+	p.circuitsMu.Lock()
+	defer p.circuitsMu.Unlock()
+
+	info, exists := p.circuits[id]
+	if !exists {
+		info = &CircuitInfo{ID: id}
+		p.circuits[id] = info
+	}
+	info.Status = status
+	if kv["PURPOSE"] != "" {
+		info.Purpose = kv["PURPOSE"]
+	}
+	if len(positional) > 0 {
+		info.Path = strings.Split(positional[0], ",")
+	}
+
+	switch status {
+	case "LAUNCHED":
+		p.circuitSeen[id] = time.Now()
+	case "BUILT":
+		if launched, ok := p.circuitSeen[id]; ok {
+			info.BuildTimeMs = time.Since(launched).Milliseconds()
+		}
+	}
+
+	return CircEvent{Circuit: *info}
+}
+
+// Circuits returns a snapshot of all circuits observed so far, keyed by ID.
+func (p *TorProvider) Circuits() map[string]CircuitInfo {
+	p.circuitsMu.Lock()
+	defer p.circuitsMu.Unlock()
+
+	snapshot := make(map[string]CircuitInfo, len(p.circuits))
+	for id, info := range p.circuits {
+		snapshot[id] = *info
+	}
+	return snapshot
+}
+
+// CountryForIP resolves an IP to its two-letter country code via Tor's own
+// GeoIP database (GETINFO ip-to-country/<ip>), caching results so repeat
+// targets don't re-query the control port on every finished stream.
+func (p *TorProvider) CountryForIP(ip string) (string, error) {
+	p.countryMu.Lock()
+	if cc, ok := p.countryCache[ip]; ok {
+		p.countryMu.Unlock()
+		return cc, nil
+	}
+	p.countryMu.Unlock()
+
+	resp, err := p.ctrl.SendCommand("GETINFO", "ip-to-country/"+ip)
+	if err != nil {
+		return "", err
+	}
+
+	cc := "??"
+	for _, line := range resp.Lines {
+		if _, value, ok := strings.Cut(line, "="); ok {
+			cc = strings.ToUpper(strings.TrimSpace(value))
+			break
+		}
+	}
+
+	p.countryMu.Lock()
+	p.countryCache[ip] = cc
+	p.countryMu.Unlock()
+	return cc, nil
+}
+
+// queryBootstrapPhase issues GETINFO status/bootstrap-phase once at startup
+// so BootstrapPercent/WaitTillBootstrapped have a value before the first
+// STATUS_CLIENT event arrives.
+func (p *TorProvider) queryBootstrapPhase() error {
+	resp, err := p.ctrl.SendCommand("GETINFO", "status/bootstrap-phase")
+	if err != nil {
+		return err
+	}
+
+	for _, line := range resp.Lines {
+		if percent, summary, ok := parseBootstrapLine(line); ok {
+			p.updateBootstrapStatus(percent, summary)
+			return nil
+		}
+	}
+	return nil
+}
+
+// handleStatusClientEvent reacts to a "650 STATUS_CLIENT ..." event line,
+// tracking bootstrap progress and NETWORK_LIVENESS transitions.
+func (p *TorProvider) handleStatusClientEvent(line string) {
+	if percent, summary, ok := parseBootstrapLine(line); ok {
+		p.updateBootstrapStatus(percent, summary)
+		return
+	}
+
+	if strings.Contains(line, "NETWORK_LIVENESS") {
+		liveness := "unknown"
+		switch {
+		case strings.Contains(line, "UP"):
+			liveness = "up"
+		case strings.Contains(line, "DOWN"):
+			liveness = "down"
+		}
+
+		p.statusMu.Lock()
+		changed := p.networkLiveness != liveness
+		p.networkLiveness = liveness
+		p.statusMu.Unlock()
+
+		if changed {
+			color := ColorGreen
+			if liveness == "down" {
+				color = ColorRed
+			}
+			fmt.Printf("[%s] %sTor network liveness: %s%s\n",
+				time.Now().Format("15:04:05"), color, strings.ToUpper(liveness), ColorReset)
+		}
+	}
+}
+
+// parseBootstrapLine extracts PROGRESS=<n> and SUMMARY="..." from a
+// bootstrap status line, whether it came from GETINFO or a STATUS_CLIENT
+// BOOTSTRAP event.
+func parseBootstrapLine(line string) (percent int, summary string, ok bool) {
+	if !strings.Contains(line, "BOOTSTRAP") {
+		return 0, "", false
+	}
+
+	_, kv := ParseKeywordArgs(splitKeywords(line))
+	if v, err := strconv.Atoi(kv["PROGRESS"]); err == nil {
+		percent, ok = v, true
+	}
+	summary = kv["SUMMARY"]
+	return percent, summary, ok
+}
+
+// updateBootstrapStatus records the new percentage, fires the registered
+// callback, and unblocks WaitTillBootstrapped once 100% is reached.
+func (p *TorProvider) updateBootstrapStatus(percent int, summary string) {
+	p.statusMu.Lock()
+	p.bootstrapPercent = percent
+	cb := p.statusCallback
+	p.statusMu.Unlock()
+
+	if cb != nil {
+		cb(percent, summary)
+	}
+
+	fmt.Printf("[%s] %sTor bootstrap: %d%% (%s)%s\n",
+		time.Now().Format("15:04:05"), ColorCyan, percent, summary, ColorReset)
+
+	if percent >= 100 {
+		p.bootstrappedOnce.Do(func() { close(p.bootstrapped) })
+	}
+}
+
+// SetStatusCallback registers a function invoked on every bootstrap phase change.
+func (p *TorProvider) SetStatusCallback(cb func(percent int, summary string)) {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	p.statusCallback = cb
+}
+
+// WaitTillBootstrapped blocks until Tor reports 100% bootstrap progress.
+func (p *TorProvider) WaitTillBootstrapped() {
+	<-p.bootstrapped
+}
+
+// BootstrapPercent returns the most recently observed bootstrap percentage.
+func (p *TorProvider) BootstrapPercent() int {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	return p.bootstrapPercent
+}
+
+func (p *TorProvider) Close() error {
+	if p.ctrl == nil {
+		return nil
+	}
+	return p.ctrl.Close()
+}